@@ -2,17 +2,74 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"golang.org/x/sync/errgroup"
 )
 
+// Counters exposed at /debug/vars (expvar). They are not part of the
+// /metrics output, which only carries the instance-count gauges below.
+var (
+	describeCallsTotal     = expvar.NewInt("describe_calls_total")
+	startCallsTotal        = expvar.NewInt("start_calls_total")
+	stopCallsTotal         = expvar.NewInt("stop_calls_total")
+	apiErrorsTotal         = expvar.NewInt("api_errors_total")
+	refreshDurationSeconds = expvar.NewFloat("refresh_duration_seconds")
+)
+
+// snapshot holds the most recent getInstances result, used to derive the
+// per-state/region/platform instance-count gauges served on /metrics.
+var (
+	snapshotMu sync.Mutex
+	snapshot   []Instance
+)
+
+func setSnapshot(instances []Instance) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	snapshot = instances
+}
+
+// writeMetrics renders the instance-count gauges in Prometheus text
+// exposition format from the last getInstances snapshot.
+func writeMetrics(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	snapshotMu.Lock()
+	instances := snapshot
+	snapshotMu.Unlock()
+
+	type groupKey struct {
+		state, region, platform string
+	}
+	counts := map[groupKey]int{}
+	for _, instance := range instances {
+		counts[groupKey{instance.State, instance.Region, instance.Platform}]++
+	}
+
+	fmt.Fprintln(w, "# HELP ec2_manager_instances Number of EC2 instances by state, region and platform.")
+	fmt.Fprintln(w, "# TYPE ec2_manager_instances gauge")
+	for key, count := range counts {
+		fmt.Fprintf(w, "ec2_manager_instances{state=%q,region=%q,platform=%q} %d\n", key.state, key.region, key.platform, count)
+	}
+}
+
 type Instance struct {
 	GlobalIP        string
 	InstanceId      string
@@ -21,102 +78,372 @@ type Instance struct {
 	PrivateIp       string
 	SecurityGroupId string
 	State           string
+	Region          string
+	Profile         string
+}
+
+// EC2API mirrors the subset of *ec2.Client used by EC2Client. Depending on
+// the interface rather than the concrete SDK client lets tests inject a
+// fake implementation instead of hitting AWS.
+type EC2API interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error)
+	StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error)
+	TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error)
+	RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+	DescribeKeyPairs(ctx context.Context, params *ec2.DescribeKeyPairsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeKeyPairsOutput, error)
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+}
+
+// regionClient pairs a region/profile scoped EC2 API client with the
+// coordinates used to tag the instances it returns.
+type regionClient struct {
+	client  EC2API
+	region  string
+	profile string
 }
 
+// EC2Client fans DescribeInstances out across every region/profile pair it
+// was constructed with, so a single call to getInstances surveys the whole
+// fleet rather than one account/region. Per-instance calls (start, stop,
+// terminate, launch) go to the single pair named by the caller.
 type EC2Client struct {
-	client *ec2.Client
-	ctx    context.Context
+	clients []regionClient
+	ctx     context.Context
 }
 
-func NewEC2Client(profile string) (*EC2Client, error) {
+// NewEC2Client builds one underlying SDK client per (region, profile)
+// combination. profiles may be empty, in which case the default profile
+// is used for every region.
+func NewEC2Client(regions []string, profiles []string) (*EC2Client, error) {
 	ctx := context.TODO()
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithSharedConfigProfile(profile),
-		config.WithRegion("ap-northeast-1"),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("unable to load SDK config: %v", err)
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("at least one region is required")
+	}
+	if len(profiles) == 0 {
+		profiles = []string{"default"}
+	}
+
+	var clients []regionClient
+	for _, profile := range profiles {
+		for _, region := range regions {
+			cfg, err := config.LoadDefaultConfig(ctx,
+				config.WithSharedConfigProfile(profile),
+				config.WithRegion(region),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load SDK config for profile %q region %q: %v", profile, region, err)
+			}
+
+			clients = append(clients, regionClient{
+				client:  ec2.NewFromConfig(cfg),
+				region:  region,
+				profile: profile,
+			})
+		}
 	}
 
 	return &EC2Client{
-		client: ec2.NewFromConfig(cfg),
-		ctx:    ctx,
+		clients: clients,
+		ctx:     ctx,
 	}, nil
 }
 
-func (c *EC2Client) getInstances() ([]Instance, error) {
-	input := &ec2.DescribeInstancesInput{}
-	result, err := c.client.DescribeInstances(c.ctx, input)
-	if err != nil {
+// getInstances aggregates DescribeInstances across every region/profile
+// pair concurrently. A failure against one pair does not abort the others;
+// it is instead surfaced as a warning row in the merged result. filters, if
+// non-empty, is passed straight through to DescribeInstancesInput so large
+// accounts can be narrowed server-side (e.g. by tag or state).
+func (c *EC2Client) getInstances(filters []types.Filter) ([]Instance, error) {
+	start := time.Now()
+	defer func() { refreshDurationSeconds.Set(time.Since(start).Seconds()) }()
+
+	results := make([][]Instance, len(c.clients))
+
+	g, ctx := errgroup.WithContext(c.ctx)
+	for i, rc := range c.clients {
+		i, rc := i, rc
+		g.Go(func() error {
+			instances, err := describeInstances(ctx, rc, filters)
+			if err != nil {
+				apiErrorsTotal.Add(1)
+				instances = []Instance{{
+					InstanceId: "-",
+					Name:       fmt.Sprintf("⚠ %v", err),
+					State:      "error",
+					Region:     rc.region,
+					Profile:    rc.profile,
+				}}
+			}
+			results[i] = instances
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
 	var instances []Instance
-	for _, reservation := range result.Reservations {
-		for _, instance := range reservation.Instances {
-			var name string
-			for _, tag := range instance.Tags {
-				if *tag.Key == "Name" {
-					name = *tag.Value
-					break
+	for _, r := range results {
+		instances = append(instances, r...)
+	}
+	return instances, nil
+}
+
+// describeInstances walks every page of DescribeInstances so accounts with
+// more than one page of reservations are fully enumerated.
+func describeInstances(ctx context.Context, rc regionClient, filters []types.Filter) ([]Instance, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(rc.client, &ec2.DescribeInstancesInput{Filters: filters})
+
+	var instances []Instance
+	for paginator.HasMorePages() {
+		describeCallsTotal.Add(1)
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				var name string
+				for _, tag := range instance.Tags {
+					if *tag.Key == "Name" {
+						name = *tag.Value
+						break
+					}
 				}
-			}
 
-			// Platform の取得（Windowsかどうか）
-			platform := "None"
-			if instance.Platform != "" {
-				platform = string(instance.Platform)
-			}
+				// Platform の取得（Windowsかどうか）
+				platform := "None"
+				if instance.Platform != "" {
+					platform = string(instance.Platform)
+				}
 
-			// Public IP の取得
-			var publicIP string
-			if instance.PublicIpAddress != nil {
-				publicIP = *instance.PublicIpAddress
-			}
+				// Public IP の取得
+				var publicIP string
+				if instance.PublicIpAddress != nil {
+					publicIP = *instance.PublicIpAddress
+				}
 
-			// Private IP の取得
-			var privateIP string
-			if len(instance.NetworkInterfaces) > 0 && instance.NetworkInterfaces[0].PrivateIpAddress != nil {
-				privateIP = *instance.NetworkInterfaces[0].PrivateIpAddress
-			}
+				// Private IP の取得
+				var privateIP string
+				if len(instance.NetworkInterfaces) > 0 && instance.NetworkInterfaces[0].PrivateIpAddress != nil {
+					privateIP = *instance.NetworkInterfaces[0].PrivateIpAddress
+				}
 
-			// Security Group IDs の取得
-			var sgIDs []string
-			for _, sg := range instance.SecurityGroups {
-				sgIDs = append(sgIDs, *sg.GroupId)
-			}
+				// Security Group IDs の取得
+				var sgIDs []string
+				for _, sg := range instance.SecurityGroups {
+					sgIDs = append(sgIDs, *sg.GroupId)
+				}
 
-			instances = append(instances, Instance{
-				GlobalIP:        publicIP,
-				InstanceId:      *instance.InstanceId,
-				Name:            name,
-				Platform:        platform,
-				PrivateIp:       privateIP,
-				SecurityGroupId: joinStrings(sgIDs, ", "),
-				State:           string(instance.State.Name),
-			})
+				instances = append(instances, Instance{
+					GlobalIP:        publicIP,
+					InstanceId:      *instance.InstanceId,
+					Name:            name,
+					Platform:        platform,
+					PrivateIp:       privateIP,
+					SecurityGroupId: joinStrings(sgIDs, ", "),
+					State:           string(instance.State.Name),
+					Region:          rc.region,
+					Profile:         rc.profile,
+				})
+			}
 		}
 	}
 
 	return instances, nil
 }
 
-func (c *EC2Client) startInstance(instanceID string) error {
+// clientFor returns the underlying client for the given region/profile pair,
+// so start/stop requests are routed to the account they were listed from.
+func (c *EC2Client) clientFor(region, profile string) (EC2API, error) {
+	for _, rc := range c.clients {
+		if rc.region == region && rc.profile == profile {
+			return rc.client, nil
+		}
+	}
+	return nil, fmt.Errorf("no client configured for region %q profile %q", region, profile)
+}
+
+func (c *EC2Client) startInstance(instanceID, region, profile string) error {
+	startCallsTotal.Add(1)
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		apiErrorsTotal.Add(1)
+		return err
+	}
 	input := &ec2.StartInstancesInput{
 		InstanceIds: []string{instanceID},
 	}
-	_, err := c.client.StartInstances(c.ctx, input)
+	_, err = client.StartInstances(c.ctx, input)
+	if err != nil {
+		apiErrorsTotal.Add(1)
+	}
 	return err
 }
 
-func (c *EC2Client) stopInstance(instanceID string) error {
+func (c *EC2Client) stopInstance(instanceID, region, profile string) error {
+	stopCallsTotal.Add(1)
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		apiErrorsTotal.Add(1)
+		return err
+	}
 	input := &ec2.StopInstancesInput{
 		InstanceIds: []string{instanceID},
 	}
-	_, err := c.client.StopInstances(c.ctx, input)
+	_, err = client.StopInstances(c.ctx, input)
+	if err != nil {
+		apiErrorsTotal.Add(1)
+	}
 	return err
 }
 
+// terminateInstance calls ec2:TerminateInstances against the client owning
+// the given region/profile.
+func (c *EC2Client) terminateInstance(instanceID, region, profile string) error {
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		return err
+	}
+	input := &ec2.TerminateInstancesInput{
+		InstanceIds: []string{instanceID},
+	}
+	_, err = client.TerminateInstances(c.ctx, input)
+	return err
+}
+
+// launchParams bundles the fields collected by the launch form.
+type launchParams struct {
+	Region          string
+	Profile         string
+	AMI             string
+	InstanceType    string
+	KeyName         string
+	SecurityGroupID string
+	SubnetID        string
+}
+
+// runInstance launches a single instance and returns its instance ID.
+func (c *EC2Client) runInstance(p launchParams) (string, error) {
+	client, err := c.clientFor(p.Region, p.Profile)
+	if err != nil {
+		return "", err
+	}
+
+	input := &ec2.RunInstancesInput{
+		ImageId:          aws.String(p.AMI),
+		InstanceType:     types.InstanceType(p.InstanceType),
+		KeyName:          aws.String(p.KeyName),
+		MinCount:         aws.Int32(1),
+		MaxCount:         aws.Int32(1),
+		SecurityGroupIds: []string{p.SecurityGroupID},
+		SubnetId:         aws.String(p.SubnetID),
+	}
+
+	result, err := client.RunInstances(c.ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Instances) == 0 {
+		return "", fmt.Errorf("RunInstances returned no instances")
+	}
+
+	return *result.Instances[0].InstanceId, nil
+}
+
+// waitForPublicIP polls DescribeInstances with backoff until the freshly
+// launched instance has a PublicIpAddress associated (this typically takes
+// a few seconds) or the context deadline passes.
+func (c *EC2Client) waitForPublicIP(ctx context.Context, instanceID, region, profile string) (string, error) {
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		return "", err
+	}
+
+	backoff := 1 * time.Second
+	for {
+		result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(result.Reservations) > 0 && len(result.Reservations[0].Instances) > 0 {
+			instance := result.Reservations[0].Instances[0]
+			if instance.PublicIpAddress != nil {
+				return *instance.PublicIpAddress, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < 10*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// describeKeyPairs lists key pair names available in the given region/profile.
+func (c *EC2Client) describeKeyPairs(region, profile string) ([]string, error) {
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.DescribeKeyPairs(c.ctx, &ec2.DescribeKeyPairsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, kp := range result.KeyPairs {
+		names = append(names, *kp.KeyName)
+	}
+	return names, nil
+}
+
+// describeSecurityGroupIDs lists security group IDs available in the given
+// region/profile.
+func (c *EC2Client) describeSecurityGroupIDs(region, profile string) ([]string, error) {
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.DescribeSecurityGroups(c.ctx, &ec2.DescribeSecurityGroupsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, sg := range result.SecurityGroups {
+		ids = append(ids, *sg.GroupId)
+	}
+	return ids, nil
+}
+
+// describeSubnetIDs lists subnet IDs available in the given region/profile.
+func (c *EC2Client) describeSubnetIDs(region, profile string) ([]string, error) {
+	client, err := c.clientFor(region, profile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.DescribeSubnets(c.ctx, &ec2.DescribeSubnetsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, subnet := range result.Subnets {
+		ids = append(ids, *subnet.SubnetId)
+	}
+	return ids, nil
+}
+
 func joinStrings(slice []string, sep string) string {
 	if len(slice) == 0 {
 		return ""
@@ -128,13 +455,80 @@ func joinStrings(slice []string, sep string) string {
 	return result
 }
 
+// splitCSV splits a comma-separated flag value into trimmed, non-empty parts.
+func splitCSV(value string) []string {
+	var parts []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// filterNames maps the shorthand keys accepted by -filter to the EC2
+// DescribeInstances filter name. A bare "tag:Key" is passed through as-is.
+var filterNames = map[string]string{
+	"State":    "instance-state-name",
+	"Platform": "platform",
+}
+
+// parseFilterFlag turns "tag:Env=prod,State=running" into the equivalent
+// DescribeInstancesInput filters, so large accounts can be narrowed
+// server-side instead of scanning every instance client-side.
+func parseFilterFlag(value string) []types.Filter {
+	var filters []types.Filter
+	for _, pair := range splitCSV(value) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, val := kv[0], kv[1]
+		name := key
+		if mapped, ok := filterNames[key]; ok {
+			name = mapped
+		}
+		filters = append(filters, types.Filter{
+			Name:   aws.String(name),
+			Values: []string{val},
+		})
+	}
+	return filters
+}
+
+// instanceColumns lists the sortable table columns in display order, with
+// the extractor used to sort by that column.
+var instanceColumns = []struct {
+	header string
+	value  func(Instance) string
+}{
+	{"GlobalIP", func(i Instance) string { return i.GlobalIP }},
+	{"InstanceId", func(i Instance) string { return i.InstanceId }},
+	{"Name", func(i Instance) string { return i.Name }},
+	{"Platform", func(i Instance) string { return i.Platform }},
+	{"PrivateIp", func(i Instance) string { return i.PrivateIp }},
+	{"SecurityGroupId", func(i Instance) string { return i.SecurityGroupId }},
+	{"State", func(i Instance) string { return i.State }},
+}
+
 func main() {
 	// コマンドライン引数の処理
-	profile := flag.String("profile", "default", "AWS profile name")
+	regionsFlag := flag.String("regions", "ap-northeast-1", "Comma-separated list of AWS regions to survey")
+	profilesFlag := flag.String("profiles", "default", "Comma-separated list of AWS profiles to survey")
+	filterFlag := flag.String("filter", "", "Server-side filter, e.g. tag:Env=prod,State=running")
+	sshUserFlag := flag.String("ssh-user", "", "Username to connect with over ssh (or RDP for Windows instances); defaults per-Platform to Administrator for Windows, ec2-user otherwise")
+	sshKeyFlag := flag.String("ssh-key", "", "Path to the SSH private key to use for ssh connections")
+	refreshFlag := flag.Duration("refresh", 10*time.Second, "Auto-refresh interval, e.g. 10s (0 disables)")
+	metricsAddrFlag := flag.String("metrics-addr", "", "Address to expose Prometheus /metrics and expvar /debug/vars on, e.g. :9090 (disabled if empty)")
 	flag.Parse()
 
+	regions := splitCSV(*regionsFlag)
+	profiles := splitCSV(*profilesFlag)
+	filters := parseFilterFlag(*filterFlag)
+
 	// EC2クライアントの初期化
-	ec2Client, err := NewEC2Client(*profile)
+	ec2Client, err := NewEC2Client(regions, profiles)
 	if err != nil {
 		log.Fatalf("Failed to create EC2 client: %v", err)
 	}
@@ -143,7 +537,7 @@ func main() {
 	table := tview.NewTable().SetSelectable(true, false)
 
 	// ヘッダーの設定
-	headers := []string{"GlobalIP", "InstanceId", "Name", "Platform", "PrivateIp", "SecurityGroupId", "State"}
+	headers := []string{"GlobalIP", "InstanceId", "Name", "Platform", "PrivateIp", "SecurityGroupId", "State", "Region", "Profile"}
 	for i, header := range headers {
 		table.SetCell(0, i,
 			tview.NewTableCell(header).
@@ -151,10 +545,50 @@ func main() {
 				SetSelectable(false))
 	}
 
-	refreshTable := func(table *tview.Table) error {
-		instances, err := ec2Client.getInstances()
-		if err != nil {
-			return err
+	// view state that survives a refresh: the last-loaded instances (before
+	// search/sort are applied), the current incremental search term and the
+	// current sort column/direction.
+	var allInstances []Instance
+	searchTerm := ""
+	sortCol := -1
+	sortDesc := false
+
+	// lastStates and flashUntil track per-instance state transitions across
+	// refreshes, so a row that just changed state (e.g. stopped -> running)
+	// is briefly highlighted instead of silently updating.
+	lastStates := map[string]string{}
+	flashUntil := map[string]time.Time{}
+
+	instanceKey := func(instance Instance) string {
+		return instance.Region + "|" + instance.Profile + "|" + instance.InstanceId
+	}
+
+	renderRows := func(table *tview.Table) {
+		rows := append([]Instance(nil), allInstances...)
+		if searchTerm != "" {
+			var filtered []Instance
+			needle := strings.ToLower(searchTerm)
+			for _, instance := range rows {
+				haystack := strings.ToLower(strings.Join([]string{
+					instance.GlobalIP, instance.InstanceId, instance.Name, instance.Platform,
+					instance.PrivateIp, instance.SecurityGroupId, instance.State,
+					instance.Region, instance.Profile,
+				}, " "))
+				if strings.Contains(haystack, needle) {
+					filtered = append(filtered, instance)
+				}
+			}
+			rows = filtered
+		}
+
+		if sortCol >= 0 && sortCol < len(instanceColumns) {
+			extract := instanceColumns[sortCol].value
+			sort.SliceStable(rows, func(i, j int) bool {
+				if sortDesc {
+					return extract(rows[i]) > extract(rows[j])
+				}
+				return extract(rows[i]) < extract(rows[j])
+			})
 		}
 
 		// 既存のデータをクリア (ヘッダー以外)
@@ -163,7 +597,7 @@ func main() {
 		}
 
 		// 新しいデータの設定
-		for i, instance := range instances {
+		for i, instance := range rows {
 			row := i + 1
 			table.SetCell(row, 0, tview.NewTableCell(instance.GlobalIP))
 			table.SetCell(row, 1, tview.NewTableCell(instance.InstanceId))
@@ -181,18 +615,121 @@ func main() {
 				stateCell.SetTextColor(tcell.ColorRed)
 			case string(types.InstanceStateNamePending), string(types.InstanceStateNameStopping):
 				stateCell.SetTextColor(tcell.ColorYellow)
+			case "error":
+				stateCell.SetTextColor(tcell.ColorRed)
+			}
+			if until, ok := flashUntil[instanceKey(instance)]; ok && time.Now().Before(until) {
+				stateCell.SetBackgroundColor(tcell.ColorYellow)
 			}
 			table.SetCell(row, 6, stateCell)
+			table.SetCell(row, 7, tview.NewTableCell(instance.Region))
+			table.SetCell(row, 8, tview.NewTableCell(instance.Profile))
 		}
+	}
 
+	// applyInstances records state transitions and redraws the table from an
+	// already-fetched instance list. It touches tview widgets, so callers
+	// off the UI goroutine must run it via app.QueueUpdateDraw.
+	applyInstances := func(table *tview.Table, instances []Instance) {
+		for _, instance := range instances {
+			key := instanceKey(instance)
+			if prev, ok := lastStates[key]; ok && prev != instance.State {
+				flashUntil[key] = time.Now().Add(2 * time.Second)
+				time.AfterFunc(2*time.Second, func() {
+					app.QueueUpdateDraw(func() { renderRows(table) })
+				})
+			}
+			lastStates[key] = instance.State
+		}
+
+		allInstances = instances
+		setSnapshot(instances)
+		renderRows(table)
+	}
+
+	// refreshTable fetches instances and applies them synchronously; it is
+	// used for the initial load and the 'r' key, both already running on
+	// the UI goroutine in response to direct user action. The background
+	// auto-refresh ticker below does NOT use this - it fetches off-goroutine
+	// so a slow DescribeInstances call can't freeze the whole TUI.
+	refreshTable := func(table *tview.Table) error {
+		instances, err := ec2Client.getInstances(filters)
+		if err != nil {
+			return err
+		}
+		applyInstances(table, instances)
 		return nil
 	}
 
+	// メトリクスサーバーの起動 (任意)
+	if *metricsAddrFlag != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			writeMetrics(w)
+		})
+		server := &http.Server{Addr: *metricsAddrFlag, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("metrics server error: %v", err)
+			}
+		}()
+	}
+
 	// 初期データの読み込み
 	if err := refreshTable(table); err != nil {
 		log.Fatal(err)
 	}
 
+	// バックグラウンドの自動リフレッシュ
+	if *refreshFlag > 0 {
+		go func() {
+			ticker := time.NewTicker(*refreshFlag)
+			defer ticker.Stop()
+			for range ticker.C {
+				instances, err := ec2Client.getInstances(filters)
+				if err != nil {
+					app.QueueUpdateDraw(func() {
+						showMessage(app, table, fmt.Sprintf("Error refreshing: %v", err))
+					})
+					continue
+				}
+				app.QueueUpdateDraw(func() {
+					applyInstances(table, instances)
+				})
+			}
+		}()
+	}
+
+	// インクリメンタル検索欄
+	searchInput := tview.NewInputField().
+		SetLabel("/").
+		SetChangedFunc(func(text string) {
+			searchTerm = text
+			renderRows(table)
+		})
+
+	// レイアウトの設定 (検索欄は入力中のみ表示する)
+	help := tview.NewTextView().
+		SetText(fmt.Sprintf("[Regions: %s]  [Profiles: %s]  Keys: [s] Start  [t] Stop  [n] New  [x] Terminate  [c] SSM  [S] SSH/RDP  [/] Search  [1-7] Sort  [r] Refresh  [q] Quit",
+			strings.Join(regions, ","), strings.Join(profiles, ","))).
+		SetTextColor(tcell.ColorGreen)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(help, 1, 1, false)
+
+	searchInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			searchTerm = ""
+			searchInput.SetText("")
+			renderRows(table)
+		}
+		flex.RemoveItem(searchInput)
+		app.SetFocus(table)
+	})
+
 	// キーバインドの設定
 	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		row, _ := table.GetSelection()
@@ -201,17 +738,22 @@ func main() {
 		}
 
 		instanceID := table.GetCell(row, 1).Text
+		globalIP := table.GetCell(row, 0).Text
+		platform := table.GetCell(row, 3).Text
+		privateIP := table.GetCell(row, 4).Text
+		region := table.GetCell(row, 7).Text
+		profile := table.GetCell(row, 8).Text
 		switch event.Key() {
 		case tcell.KeyRune:
 			switch event.Rune() {
 			case 's': // 起動
-				if err := ec2Client.startInstance(instanceID); err != nil {
+				if err := ec2Client.startInstance(instanceID, region, profile); err != nil {
 					showMessage(app, table, fmt.Sprintf("Error starting instance: %v", err))
 				} else {
 					showMessage(app, table, fmt.Sprintf("Starting instance: %s", instanceID))
 				}
 			case 't': // 停止
-				if err := ec2Client.stopInstance(instanceID); err != nil {
+				if err := ec2Client.stopInstance(instanceID, region, profile); err != nil {
 					showMessage(app, table, fmt.Sprintf("Error stopping instance: %v", err))
 				} else {
 					showMessage(app, table, fmt.Sprintf("Stopping instance: %s", instanceID))
@@ -222,6 +764,36 @@ func main() {
 				if err := refreshTable(table); err != nil {
 					showMessage(app, table, fmt.Sprintf("Error refreshing: %v", err))
 				}
+			case 'n': // 新規起動
+				showLaunchForm(app, table, ec2Client, regions, profiles, refreshTable)
+			case 'x': // 削除
+				showTerminateConfirm(app, table, ec2Client, instanceID, region, profile, refreshTable)
+			case 'c': // SSM接続
+				connectSSM(app, table, instanceID, region, profile)
+			case 'S': // SSH/RDP接続
+				user := *sshUserFlag
+				if user == "" {
+					user = defaultSSHUser(platform)
+				}
+				if strings.EqualFold(platform, string(types.PlatformValuesWindows)) {
+					connectRDP(app, table, instanceID, region, profile, user)
+				} else {
+					connectSSH(app, table, user, *sshKeyFlag, globalIP, privateIP)
+				}
+			case '/': // 検索
+				searchInput.SetText(searchTerm)
+				flex.AddItem(searchInput, 1, 1, false)
+				app.SetFocus(searchInput)
+				return nil
+			case '1', '2', '3', '4', '5', '6', '7': // ソート
+				col := int(event.Rune() - '1')
+				if sortCol == col {
+					sortDesc = !sortDesc
+				} else {
+					sortCol = col
+					sortDesc = false
+				}
+				renderRows(table)
 			}
 		default:
 			// 何もしない
@@ -229,22 +801,257 @@ func main() {
 		return event
 	})
 
-	// 使い方の説明を追加
-	help := tview.NewTextView().
-		SetText(fmt.Sprintf("[Profile: %s]  Keys: [s] Start  [t] Stop  [r] Refresh  [q] Quit", *profile)).
-		SetTextColor(tcell.ColorGreen)
-
-	// レイアウトの設定
-	flex := tview.NewFlex().
-		SetDirection(tview.FlexRow).
-		AddItem(table, 0, 1, true).
-		AddItem(help, 1, 1, false)
-
 	if err := app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// showLaunchForm walks the user through picking an AMI, instance type, key
+// pair, security group and subnet, then launches the instance and hands
+// control back to the table while polling for its public IP in the
+// background, refreshing the table once one is associated.
+func showLaunchForm(app *tview.Application, table *tview.Table, client *EC2Client, regions, profiles []string, refreshTable func(*tview.Table) error) {
+	form := tview.NewForm()
+
+	region := regions[0]
+	profile := profiles[0]
+
+	keyPairs, err := client.describeKeyPairs(region, profile)
+	if err != nil {
+		showMessage(app, table, fmt.Sprintf("Error listing key pairs: %v", err))
+		return
+	}
+	securityGroups, err := client.describeSecurityGroupIDs(region, profile)
+	if err != nil {
+		showMessage(app, table, fmt.Sprintf("Error listing security groups: %v", err))
+		return
+	}
+	subnets, err := client.describeSubnetIDs(region, profile)
+	if err != nil {
+		showMessage(app, table, fmt.Sprintf("Error listing subnets: %v", err))
+		return
+	}
+
+	var ami, subnetID string
+	instanceType := "t3.micro"
+	var keyName, sgID string
+
+	form.AddDropDown("Region", regions, 0, nil).
+		AddDropDown("Profile", profiles, 0, nil).
+		AddInputField("AMI ID", "", 30, nil, func(text string) { ami = text }).
+		AddInputField("Instance Type", "t3.micro", 30, nil, func(text string) { instanceType = text }).
+		AddDropDown("Key Pair", keyPairs, 0, func(option string, _ int) { keyName = option }).
+		AddDropDown("Security Group", securityGroups, 0, func(option string, _ int) { sgID = option }).
+		AddDropDown("Subnet", subnets, 0, func(option string, _ int) { subnetID = option })
+
+	keyPairField := form.GetFormItemByLabel("Key Pair").(*tview.DropDown)
+	sgField := form.GetFormItemByLabel("Security Group").(*tview.DropDown)
+	subnetField := form.GetFormItemByLabel("Subnet").(*tview.DropDown)
+
+	// refreshDependentFields re-lists key pairs, security groups and subnets
+	// for the currently selected region/profile, since those resources are
+	// scoped per region and don't carry over when the user switches away
+	// from regions[0]/profiles[0].
+	refreshDependentFields := func() {
+		newKeyPairs, err := client.describeKeyPairs(region, profile)
+		if err != nil {
+			showMessage(app, table, fmt.Sprintf("Error listing key pairs: %v", err))
+			return
+		}
+		newSecurityGroups, err := client.describeSecurityGroupIDs(region, profile)
+		if err != nil {
+			showMessage(app, table, fmt.Sprintf("Error listing security groups: %v", err))
+			return
+		}
+		newSubnets, err := client.describeSubnetIDs(region, profile)
+		if err != nil {
+			showMessage(app, table, fmt.Sprintf("Error listing subnets: %v", err))
+			return
+		}
+
+		keyPairField.SetOptions(newKeyPairs, func(option string, _ int) { keyName = option })
+		sgField.SetOptions(newSecurityGroups, func(option string, _ int) { sgID = option })
+		subnetField.SetOptions(newSubnets, func(option string, _ int) { subnetID = option })
+	}
+
+	form.GetFormItemByLabel("Region").(*tview.DropDown).SetSelectedFunc(func(option string, _ int) {
+		region = option
+		refreshDependentFields()
+	})
+	form.GetFormItemByLabel("Profile").(*tview.DropDown).SetSelectedFunc(func(option string, _ int) {
+		profile = option
+		refreshDependentFields()
+	})
+
+	form.AddButton("Launch", func() {
+		params := launchParams{
+			Region:          region,
+			Profile:         profile,
+			AMI:             ami,
+			InstanceType:    instanceType,
+			KeyName:         keyName,
+			SecurityGroupID: sgID,
+			SubnetID:        subnetID,
+		}
+
+		instanceID, err := client.runInstance(params)
+		if err != nil {
+			app.SetRoot(table, true)
+			showMessage(app, table, fmt.Sprintf("Error launching instance: %v", err))
+			return
+		}
+		app.SetRoot(table, true)
+		showMessage(app, table, fmt.Sprintf("Launching instance: %s", instanceID))
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+			publicIP, err := client.waitForPublicIP(ctx, instanceID, region, profile)
+			app.QueueUpdateDraw(func() {
+				if err != nil {
+					showMessage(app, table, fmt.Sprintf("Instance %s launched, no public IP yet: %v", instanceID, err))
+					return
+				}
+				if rerr := refreshTable(table); rerr != nil {
+					showMessage(app, table, fmt.Sprintf("Error refreshing: %v", rerr))
+					return
+				}
+				showMessage(app, table, fmt.Sprintf("Instance %s is up at %s", instanceID, publicIP))
+			})
+		}()
+	})
+	form.AddButton("Cancel", func() {
+		app.SetRoot(table, true)
+	})
+
+	form.SetBorder(true).SetTitle("Launch Instance").SetTitleAlign(tview.AlignLeft)
+	app.SetRoot(form, true)
+}
+
+// showTerminateConfirm asks for confirmation before terminating the
+// selected instance.
+func showTerminateConfirm(app *tview.Application, table *tview.Table, client *EC2Client, instanceID, region, profile string, refreshTable func(*tview.Table) error) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Terminate instance %s?", instanceID)).
+		AddButtons([]string{"Terminate", "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			app.SetRoot(table, true)
+			if buttonLabel != "Terminate" {
+				return
+			}
+			if err := client.terminateInstance(instanceID, region, profile); err != nil {
+				showMessage(app, table, fmt.Sprintf("Error terminating instance: %v", err))
+				return
+			}
+			showMessage(app, table, fmt.Sprintf("Terminating instance: %s", instanceID))
+			if err := refreshTable(table); err != nil {
+				showMessage(app, table, fmt.Sprintf("Error refreshing: %v", err))
+			}
+		})
+
+	app.SetRoot(modal, false)
+}
+
+// defaultSSHUser returns the per-platform login name used when -ssh-user
+// was not set explicitly: Windows instances default to Administrator
+// (reached over an RDP tunnel rather than ssh), everything else to ec2-user.
+func defaultSSHUser(platform string) string {
+	if strings.EqualFold(platform, string(types.PlatformValuesWindows)) {
+		return "Administrator"
+	}
+	return "ec2-user"
+}
+
+// connectTarget picks the address ssh should reach the instance on,
+// preferring the public IP and falling back to the private one.
+func connectTarget(globalIP, privateIP string) string {
+	if globalIP != "" {
+		return globalIP
+	}
+	return privateIP
+}
+
+// runSuspended suspends the tview application, runs cmd with the terminal
+// wired up to it, and resumes the UI once the child process exits.
+func runSuspended(app *tview.Application, table *tview.Table, cmd *exec.Cmd) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	var runErr error
+	app.Suspend(func() {
+		runErr = cmd.Run()
+	})
+	if runErr != nil {
+		showMessage(app, table, fmt.Sprintf("Error running %s: %v", cmd.Args[0], runErr))
+	}
+}
+
+// connectSSM opens an SSM Session Manager session to the instance, which
+// reaches Windows and Linux instances alike without requiring a public IP
+// or an open SSH port.
+func connectSSM(app *tview.Application, table *tview.Table, instanceID, region, profile string) {
+	cmd := exec.Command("aws", "ssm", "start-session",
+		"--target", instanceID,
+		"--region", region,
+		"--profile", profile,
+	)
+	runSuspended(app, table, cmd)
+}
+
+// connectSSH shells out to ssh against the instance's public (or private)
+// IP, using sshKey as the identity file when set.
+func connectSSH(app *tview.Application, table *tview.Table, sshUser, sshKey, globalIP, privateIP string) {
+	target := connectTarget(globalIP, privateIP)
+	if target == "" {
+		showMessage(app, table, "Instance has no public or private IP to connect to")
+		return
+	}
+
+	args := []string{}
+	if sshKey != "" {
+		args = append(args, "-i", sshKey)
+	}
+	args = append(args, fmt.Sprintf("%s@%s", sshUser, target))
+
+	cmd := exec.Command("ssh", args...)
+	runSuspended(app, table, cmd)
+}
+
+// rdpLocalPort is the local end of the SSM port-forward used to reach a
+// Windows instance's RDP listener, which is never exposed directly.
+const rdpLocalPort = "13389"
+
+// connectRDP reaches a Windows instance over RDP without requiring a public
+// IP or an open security group rule: it opens an SSM port-forwarding
+// session to the instance's RDP port (3389), waits a fixed two seconds for
+// it to come up (it does not check that it did) and hands off to an RDP
+// client, tearing the tunnel down afterwards.
+func connectRDP(app *tview.Application, table *tview.Table, instanceID, region, profile, rdpUser string) {
+	tunnel := exec.Command("aws", "ssm", "start-session",
+		"--target", instanceID,
+		"--region", region,
+		"--profile", profile,
+		"--document-name", "AWS-StartPortForwardingSession",
+		"--parameters", fmt.Sprintf("portNumber=3389,localPortNumber=%s", rdpLocalPort),
+	)
+	if err := tunnel.Start(); err != nil {
+		showMessage(app, table, fmt.Sprintf("Error starting RDP tunnel: %v", err))
+		return
+	}
+	defer func() {
+		_ = tunnel.Process.Kill()
+		_ = tunnel.Wait()
+	}()
+
+	// Give the SSM port-forward a moment to come up before handing off to
+	// the RDP client.
+	time.Sleep(2 * time.Second)
+
+	cmd := exec.Command("xfreerdp", fmt.Sprintf("/v:127.0.0.1:%s", rdpLocalPort), fmt.Sprintf("/u:%s", rdpUser))
+	runSuspended(app, table, cmd)
+}
+
 func showMessage(app *tview.Application, table *tview.Table, message string) {
 	modal := tview.NewModal().
 		SetText(message).
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeEC2API implements EC2API with a caller-supplied DescribeInstances
+// response/error; the other methods are not exercised by these tests.
+type fakeEC2API struct {
+	EC2API
+	describeOutput *ec2.DescribeInstancesOutput
+	describeErr    error
+}
+
+func (f *fakeEC2API) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return f.describeOutput, f.describeErr
+}
+
+func reservation(instances ...types.Instance) types.Reservation {
+	return types.Reservation{Instances: instances}
+}
+
+func TestGetInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		fake      *fakeEC2API
+		wantCount int
+		wantErr   bool
+		check     func(t *testing.T, instances []Instance)
+	}{
+		{
+			name: "tag parsing picks Name tag",
+			fake: &fakeEC2API{describeOutput: &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{reservation(types.Instance{
+					InstanceId: aws.String("i-tagged"),
+					State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+					Tags: []types.Tag{
+						{Key: aws.String("Owner"), Value: aws.String("team-a")},
+						{Key: aws.String("Name"), Value: aws.String("web-1")},
+					},
+				})},
+			}},
+			wantCount: 1,
+			check: func(t *testing.T, instances []Instance) {
+				if instances[0].Name != "web-1" {
+					t.Errorf("Name = %q, want %q", instances[0].Name, "web-1")
+				}
+			},
+		},
+		{
+			name: "missing public IP and network interfaces leave IPs blank",
+			fake: &fakeEC2API{describeOutput: &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{reservation(types.Instance{
+					InstanceId: aws.String("i-noip"),
+					State:      &types.InstanceState{Name: types.InstanceStateNameStopped},
+				})},
+			}},
+			wantCount: 1,
+			check: func(t *testing.T, instances []Instance) {
+				if instances[0].GlobalIP != "" || instances[0].PrivateIp != "" {
+					t.Errorf("expected blank IPs, got GlobalIP=%q PrivateIp=%q", instances[0].GlobalIP, instances[0].PrivateIp)
+				}
+			},
+		},
+		{
+			name: "multiple reservations are flattened",
+			fake: &fakeEC2API{describeOutput: &ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{
+					reservation(types.Instance{InstanceId: aws.String("i-1"), State: &types.InstanceState{Name: types.InstanceStateNameRunning}}),
+					reservation(types.Instance{InstanceId: aws.String("i-2"), State: &types.InstanceState{Name: types.InstanceStateNameRunning}}),
+				},
+			}},
+			wantCount: 2,
+		},
+		{
+			name:      "DescribeInstances error becomes a warning row",
+			fake:      &fakeEC2API{describeErr: errors.New("boom")},
+			wantCount: 1,
+			wantErr:   false, // getInstances degrades to a single warning row, not a hard error
+			check: func(t *testing.T, instances []Instance) {
+				if instances[0].State != "error" {
+					t.Errorf("State = %q, want %q", instances[0].State, "error")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &EC2Client{
+				ctx: context.Background(),
+				clients: []regionClient{
+					{client: tt.fake, region: "ap-northeast-1", profile: "default"},
+				},
+			}
+
+			instances, err := client.getInstances(nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getInstances() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(instances) != tt.wantCount {
+				t.Fatalf("got %d instances, want %d", len(instances), tt.wantCount)
+			}
+			if tt.check != nil {
+				tt.check(t, instances)
+			}
+		})
+	}
+}
+
+// pagingFakeEC2API returns one canned DescribeInstancesOutput per call, in
+// order, so NewDescribeInstancesPaginator's NextToken-driven loop can be
+// exercised without a real multi-page account.
+type pagingFakeEC2API struct {
+	EC2API
+	pages []*ec2.DescribeInstancesOutput
+	calls int
+}
+
+func (f *pagingFakeEC2API) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	page := f.pages[f.calls]
+	f.calls++
+	return page, nil
+}
+
+func TestGetInstancesPagination(t *testing.T) {
+	fake := &pagingFakeEC2API{
+		pages: []*ec2.DescribeInstancesOutput{
+			{
+				Reservations: []types.Reservation{reservation(types.Instance{
+					InstanceId: aws.String("i-page1"),
+					State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+				})},
+				NextToken: aws.String("token-1"),
+			},
+			{
+				Reservations: []types.Reservation{reservation(types.Instance{
+					InstanceId: aws.String("i-page2"),
+					State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+				})},
+			},
+		},
+	}
+
+	client := &EC2Client{
+		ctx: context.Background(),
+		clients: []regionClient{
+			{client: fake, region: "ap-northeast-1", profile: "default"},
+		},
+	}
+
+	instances, err := client.getInstances(nil)
+	if err != nil {
+		t.Fatalf("getInstances() error = %v", err)
+	}
+	if fake.calls != 2 {
+		t.Errorf("DescribeInstances called %d times, want 2 (paginator should follow NextToken)", fake.calls)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("got %d instances, want 2 (one per page)", len(instances))
+	}
+	if instances[0].InstanceId != "i-page1" || instances[1].InstanceId != "i-page2" {
+		t.Errorf("got instance IDs %q, %q; want i-page1, i-page2", instances[0].InstanceId, instances[1].InstanceId)
+	}
+}